@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// OutputFormat renders one additional output artifact (a feed, a sitemap,
+// a Gemtext mirror, ...) for a built Site. The HTML pages themselves are
+// always rendered separately; OutputFormat only covers the pluggable
+// formats named in settings.json's "outputFormats" list.
+type OutputFormat interface {
+	// Name is the identifier used in settings.json's outputFormats list.
+	Name() string
+	// Render writes this format's output under site.Settings.OutputDir.
+	Render(site *Site) error
+}
+
+// registeredOutputFormats is the set of formats that can be named in
+// settings.json's "outputFormats" list.
+var registeredOutputFormats = map[string]OutputFormat{
+	"rss":     rssFormat{},
+	"atom":    atomFormat{},
+	"json":    jsonFeedFormat{},
+	"sitemap": sitemapFormat{},
+	"gemini":  geminiFormat{},
+}
+
+// renderOutputFormats renders every format named in
+// site.Settings.OutputFormats, defaulting to ["html", "rss"] for backwards
+// compatibility when unset. "html" is a no-op here, since the HTML pages
+// are always rendered by Site.renderAll.
+func renderOutputFormats(site *Site) error {
+	names := site.Settings.OutputFormats
+	if len(names) == 0 {
+		names = []string{"html", "rss"}
+	}
+
+	for _, name := range names {
+		if name == "html" {
+			continue
+		}
+
+		format, ok := registeredOutputFormats[name]
+		if !ok {
+			log.Warnf("Unknown output format '%s', skipping", name)
+			continue
+		}
+
+		if err := format.Render(site); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pageURL builds the absolute URL for a page under settings.WebsiteURL.
+func pageURL(settings Settings, page Page) string {
+	return strings.TrimRight(settings.WebsiteURL, "/") + "/" + page.Title
+}
+
+// rssFormat renders the RSS 2.0 feed.
+type rssFormat struct{}
+
+func (rssFormat) Name() string { return "rss" }
+
+func (rssFormat) Render(site *Site) error {
+	return generateRSS(site.Pages, site.Settings)
+}
+
+// AtomEntry is a single entry in an Atom 1.0 feed.
+type AtomEntry struct {
+	Title   string   `xml:"title"`
+	Link    AtomLink `xml:"link"`
+	ID      string   `xml:"id"`
+	Updated string   `xml:"updated"`
+	Summary string   `xml:"summary"`
+}
+
+// AtomLink represents an Atom <link> element.
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+// AtomFeed is the root element of an Atom 1.0 feed.
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	Link    AtomLink    `xml:"link"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+// atomFormat renders an Atom 1.0 feed.
+type atomFormat struct{}
+
+func (atomFormat) Name() string { return "atom" }
+
+func (atomFormat) Render(site *Site) error {
+	settings := site.Settings
+	pages := site.Pages
+
+	var entries []AtomEntry
+	for _, page := range pages {
+		link := pageURL(settings, page)
+		entries = append(entries, AtomEntry{
+			Title:   displayTitle(page),
+			Link:    AtomLink{Href: link},
+			ID:      link,
+			Updated: page.ModificationDate.Format(time.RFC3339),
+			Summary: page.Content,
+		})
+	}
+
+	updated := time.Now()
+	if len(pages) > 0 {
+		updated = pages[0].ModificationDate
+	}
+
+	feed := AtomFeed{
+		Xmlns:   "http://www.w3.org/2005/Atom",
+		Title:   settings.WebsiteName,
+		Link:    AtomLink{Href: settings.WebsiteURL},
+		ID:      settings.WebsiteURL,
+		Updated: updated.Format(time.RFC3339),
+		Entries: entries,
+	}
+
+	xmlContent, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(xml.Header)
+	buf.Write(xmlContent)
+
+	return os.WriteFile(filepath.Join(settings.OutputDir, "atom.xml"), buf.Bytes(), os.ModePerm)
+}
+
+// JSONFeedItem is a single item in a JSON Feed 1.1 document.
+type JSONFeedItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url"`
+	Title         string `json:"title"`
+	ContentHTML   string `json:"content_html"`
+	DatePublished string `json:"date_published"`
+}
+
+// JSONFeedDocument is the top-level object of a JSON Feed 1.1 document.
+type JSONFeedDocument struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+// jsonFeedFormat renders a JSON Feed 1.1 document.
+type jsonFeedFormat struct{}
+
+func (jsonFeedFormat) Name() string { return "json" }
+
+func (jsonFeedFormat) Render(site *Site) error {
+	settings := site.Settings
+	pages := site.Pages
+
+	var items []JSONFeedItem
+	for _, page := range pages {
+		link := pageURL(settings, page)
+		items = append(items, JSONFeedItem{
+			ID:            link,
+			URL:           link,
+			Title:         displayTitle(page),
+			ContentHTML:   page.Content,
+			DatePublished: page.ModificationDate.Format(time.RFC3339),
+		})
+	}
+
+	document := JSONFeedDocument{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       settings.WebsiteName,
+		HomePageURL: settings.WebsiteURL,
+		FeedURL:     strings.TrimRight(settings.WebsiteURL, "/") + "/feed.json",
+		Description: settings.WebsiteDescription,
+		Items:       items,
+	}
+
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(settings.OutputDir, "feed.json"), content, os.ModePerm)
+}
+
+// sitemapURL is a single <url> entry in a sitemap.xml.
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// sitemapURLSet is the root <urlset> element of a sitemap.xml.
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// sitemapFormat renders a sitemap.xml listing every page.
+type sitemapFormat struct{}
+
+func (sitemapFormat) Name() string { return "sitemap" }
+
+func (sitemapFormat) Render(site *Site) error {
+	settings := site.Settings
+
+	urls := []sitemapURL{
+		{Loc: strings.TrimRight(settings.WebsiteURL, "/") + "/", LastMod: time.Now().Format("2006-01-02")},
+	}
+	for _, page := range site.Pages {
+		urls = append(urls, sitemapURL{
+			Loc:     pageURL(settings, page),
+			LastMod: page.ModificationDate.Format("2006-01-02"),
+		})
+	}
+
+	urlSet := sitemapURLSet{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		URLs:  urls,
+	}
+
+	content, err := xml.MarshalIndent(urlSet, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(xml.Header)
+	buf.Write(content)
+
+	return os.WriteFile(filepath.Join(settings.OutputDir, "sitemap.xml"), buf.Bytes(), os.ModePerm)
+}
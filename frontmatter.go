@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatterDateLayouts lists the date formats accepted in a front matter
+// "date" field, tried in order.
+var frontMatterDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseFrontMatterDate parses a front matter "date" value using the first
+// matching layout in frontMatterDateLayouts.
+func parseFrontMatterDate(value string) (time.Time, error) {
+	var err error
+	for _, layout := range frontMatterDateLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// FrontMatter represents the metadata block at the top of a markdown file,
+// delimited by "---" (YAML) or "+++" (TOML).
+type FrontMatter struct {
+	Title      string
+	Date       string
+	Draft      bool
+	Tags       []string
+	Categories []string
+	Slug       string
+	Aliases    []string
+	Params     map[string]interface{}
+}
+
+// splitFrontMatter separates a leading "---"/"+++" delimited front matter
+// block from the rest of the markdown content. The second return value is
+// the delimiter that was found ("---", "+++", or "" if no front matter is
+// present).
+func splitFrontMatter(content []byte) (raw []byte, delim string, body []byte) {
+	for _, d := range []string{"---", "+++"} {
+		prefix := []byte(d + "\n")
+		if !bytes.HasPrefix(content, prefix) {
+			continue
+		}
+
+		rest := content[len(prefix):]
+		closing := []byte("\n" + d)
+		idx := bytes.Index(rest, closing)
+		if idx == -1 {
+			continue
+		}
+
+		raw = rest[:idx]
+		body = rest[idx+len(closing):]
+		body = bytes.TrimPrefix(body, []byte("\n"))
+
+		return raw, d, body
+	}
+
+	return nil, "", content
+}
+
+// parseFrontMatter decodes a raw front matter block into a FrontMatter,
+// choosing the YAML or TOML decoder based on the delimiter returned by
+// splitFrontMatter.
+func parseFrontMatter(raw []byte, delim string) (FrontMatter, error) {
+	data := make(map[string]interface{})
+
+	var err error
+	switch delim {
+	case "+++":
+		err = toml.Unmarshal(raw, &data)
+	default:
+		err = yaml.Unmarshal(raw, &data)
+	}
+	if err != nil {
+		return FrontMatter{}, err
+	}
+
+	fm := FrontMatter{Params: make(map[string]interface{})}
+	for key, value := range data {
+		switch strings.ToLower(key) {
+		case "title":
+			fm.Title, _ = value.(string)
+		case "date":
+			// TOML decodes an unquoted date such as 2020-06-15 as a
+			// time.Time, while yaml.v3 keeps a YAML date as a string when
+			// decoding into interface{}, so both representations need to
+			// be accepted here.
+			switch v := value.(type) {
+			case string:
+				fm.Date = v
+			case time.Time:
+				fm.Date = v.Format(time.RFC3339)
+			}
+		case "draft":
+			fm.Draft, _ = value.(bool)
+		case "tags":
+			fm.Tags = toStringSlice(value)
+		case "categories":
+			fm.Categories = toStringSlice(value)
+		case "slug":
+			fm.Slug, _ = value.(string)
+		case "aliases":
+			fm.Aliases = toStringSlice(value)
+		default:
+			fm.Params[key] = value
+		}
+	}
+
+	return fm, nil
+}
+
+// toStringSlice coerces a decoded YAML/TOML value (typically []interface{}
+// or []string) into a []string, skipping entries that aren't strings.
+func toStringSlice(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
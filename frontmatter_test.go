@@ -0,0 +1,180 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantRaw   string
+		wantDelim string
+		wantBody  string
+	}{
+		{
+			name:      "yaml delimiter",
+			content:   "---\ntitle: Hello\n---\nbody text\n",
+			wantRaw:   "title: Hello",
+			wantDelim: "---",
+			wantBody:  "body text\n",
+		},
+		{
+			name:      "toml delimiter",
+			content:   "+++\ntitle = \"Hello\"\n+++\nbody text\n",
+			wantRaw:   "title = \"Hello\"",
+			wantDelim: "+++",
+			wantBody:  "body text\n",
+		},
+		{
+			name:      "no front matter",
+			content:   "just a body\n",
+			wantRaw:   "",
+			wantDelim: "",
+			wantBody:  "just a body\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, delim, body := splitFrontMatter([]byte(tt.content))
+			if string(raw) != tt.wantRaw {
+				t.Errorf("raw = %q, want %q", raw, tt.wantRaw)
+			}
+			if delim != tt.wantDelim {
+				t.Errorf("delim = %q, want %q", delim, tt.wantDelim)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestParseFrontMatterYAML(t *testing.T) {
+	raw := []byte("title: Hello World\ndraft: true\ntags:\n  - go\n  - cli\nslug: custom-slug\nseries: backend\n")
+
+	fm, err := parseFrontMatter(raw, "---")
+	if err != nil {
+		t.Fatalf("parseFrontMatter() error = %v", err)
+	}
+
+	if fm.Title != "Hello World" {
+		t.Errorf("Title = %q, want %q", fm.Title, "Hello World")
+	}
+	if !fm.Draft {
+		t.Error("Draft = false, want true")
+	}
+	if len(fm.Tags) != 2 || fm.Tags[0] != "go" || fm.Tags[1] != "cli" {
+		t.Errorf("Tags = %v, want [go cli]", fm.Tags)
+	}
+	if fm.Slug != "custom-slug" {
+		t.Errorf("Slug = %q, want %q", fm.Slug, "custom-slug")
+	}
+	if fm.Params["series"] != "backend" {
+		t.Errorf("Params[series] = %v, want %q", fm.Params["series"], "backend")
+	}
+}
+
+func TestParseFrontMatterTOML(t *testing.T) {
+	raw := []byte("title = \"Hello World\"\ndraft = true\ntags = [\"go\", \"cli\"]\nslug = \"custom-slug\"\n")
+
+	fm, err := parseFrontMatter(raw, "+++")
+	if err != nil {
+		t.Fatalf("parseFrontMatter() error = %v", err)
+	}
+
+	if fm.Title != "Hello World" {
+		t.Errorf("Title = %q, want %q", fm.Title, "Hello World")
+	}
+	if !fm.Draft {
+		t.Error("Draft = false, want true")
+	}
+	if len(fm.Tags) != 2 || fm.Tags[0] != "go" || fm.Tags[1] != "cli" {
+		t.Errorf("Tags = %v, want [go cli]", fm.Tags)
+	}
+	if fm.Slug != "custom-slug" {
+		t.Errorf("Slug = %q, want %q", fm.Slug, "custom-slug")
+	}
+}
+
+// writeMarkdownFile writes content to dir/name and returns its path and
+// os.FileInfo, as generatePage expects from filepath.Walk.
+func writeMarkdownFile(t *testing.T, dir string, name string, content string) (string, os.FileInfo) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile(%s) error = %v", path, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat(%s) error = %v", path, err)
+	}
+
+	return path, info
+}
+
+func TestGeneratePageDraftSkip(t *testing.T) {
+	dir := t.TempDir()
+	path, info := writeMarkdownFile(t, dir, "2024-01-02-draft-post.md", "---\ndraft: true\n---\nbody\n")
+
+	page, err := generatePage(path, info, dir, false, nil)
+	if err != nil {
+		t.Fatalf("generatePage() error = %v", err)
+	}
+	if page != nil {
+		t.Fatalf("generatePage() with buildDrafts=false = %+v, want nil", page)
+	}
+
+	page, err = generatePage(path, info, dir, true, nil)
+	if err != nil {
+		t.Fatalf("generatePage() error = %v", err)
+	}
+	if page == nil {
+		t.Fatal("generatePage() with buildDrafts=true = nil, want a page")
+	}
+	if !page.Draft {
+		t.Error("page.Draft = false, want true")
+	}
+}
+
+func TestGeneratePageSlugOverride(t *testing.T) {
+	dir := t.TempDir()
+	path, info := writeMarkdownFile(t, dir, "2024-01-02-original-name.md", "---\nslug: custom-slug\n---\nbody\n")
+
+	page, err := generatePage(path, info, dir, false, nil)
+	if err != nil {
+		t.Fatalf("generatePage() error = %v", err)
+	}
+	if page == nil {
+		t.Fatal("generatePage() = nil, want a page")
+	}
+
+	want := "custom-slug.html"
+	if page.Title != want {
+		t.Errorf("page.Title = %q, want %q", page.Title, want)
+	}
+}
+
+func TestGeneratePageDateOverride(t *testing.T) {
+	dir := t.TempDir()
+	path, info := writeMarkdownFile(t, dir, "2024-01-02-post.md", "---\ndate: 2020-06-15\n---\nbody\n")
+
+	page, err := generatePage(path, info, dir, false, nil)
+	if err != nil {
+		t.Fatalf("generatePage() error = %v", err)
+	}
+	if page == nil {
+		t.Fatal("generatePage() = nil, want a page")
+	}
+
+	want := time.Date(2020, 6, 15, 0, 0, 0, 0, time.UTC)
+	if !page.ModificationDate.Equal(want) {
+		t.Errorf("page.ModificationDate = %v, want %v", page.ModificationDate, want)
+	}
+}
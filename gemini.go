@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+// geminiFormat renders each page as Gemtext (.gmi) under
+// outputDir/gemini/{slug}.gmi, by walking goldmark's parsed AST and mapping
+// nodes to Gemini line types: headings to "#"/"##"/"###", paragraphs to
+// plain lines (with any links pulled out to standalone "=>" lines
+// immediately after the paragraph), list items to "* ", code blocks to
+// "```" fenced preformatted blocks, blockquotes to "> " lines, and images to
+// "=> url alt-text" link lines. This is Kiln's gmi-to-html pipeline run in
+// reverse.
+type geminiFormat struct{}
+
+func (geminiFormat) Name() string { return "gemini" }
+
+func (geminiFormat) Render(site *Site) error {
+	outputDir := filepath.Join(site.Settings.OutputDir, "gemini")
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	for _, page := range site.Pages {
+		gmi, err := renderGemtext(page.Markdown)
+		if err != nil {
+			return fmt.Errorf("failed to render gemtext for '%s': %w", page.Title, err)
+		}
+
+		name := strings.TrimSuffix(page.Title, ".html")
+		outputPath := filepath.Join(outputDir, name+".gmi")
+		if err := os.WriteFile(outputPath, []byte(gmi), os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// gemtextLink is a link discovered among a paragraph's inline children,
+// queued to be emitted as a standalone "=>" line right after the paragraph.
+type gemtextLink struct {
+	url   string
+	label string
+}
+
+// renderGemtext converts markdown source into Gemtext by walking goldmark's
+// parsed AST.
+func renderGemtext(source []byte) (string, error) {
+	md := goldmark.New(goldmark.WithExtensions(extension.GFM))
+	doc := md.Parser().Parse(text.NewReader(source))
+
+	var out strings.Builder
+	err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+		case *ast.Heading:
+			// Gemtext has only three heading levels, so deeper headings
+			// are rendered as "###".
+			level := node.Level
+			if level > 3 {
+				level = 3
+			}
+			out.WriteString(strings.Repeat("#", level) + " " + inlineText(node, source) + "\n")
+			for _, link := range collectLinks(node, source) {
+				out.WriteString(fmt.Sprintf("=> %s %s\n", link.url, link.label))
+			}
+			return ast.WalkSkipChildren, nil
+
+		case *ast.Paragraph:
+			out.WriteString(inlineText(node, source) + "\n")
+			for _, link := range collectLinks(node, source) {
+				out.WriteString(fmt.Sprintf("=> %s %s\n", link.url, link.label))
+			}
+			return ast.WalkSkipChildren, nil
+
+		case *ast.ListItem:
+			out.WriteString("* " + strings.TrimSpace(inlineText(node, source)) + "\n")
+			for _, link := range collectLinks(node, source) {
+				out.WriteString(fmt.Sprintf("=> %s %s\n", link.url, link.label))
+			}
+			return ast.WalkSkipChildren, nil
+
+		case *ast.FencedCodeBlock:
+			out.WriteString("```\n")
+			out.Write(codeBlockSource(node, source))
+			out.WriteString("```\n")
+			return ast.WalkSkipChildren, nil
+
+		case *ast.CodeBlock:
+			out.WriteString("```\n")
+			out.Write(codeBlockSource(node, source))
+			out.WriteString("```\n")
+			return ast.WalkSkipChildren, nil
+
+		case *ast.Blockquote:
+			out.WriteString("> " + inlineText(node, source) + "\n")
+			return ast.WalkSkipChildren, nil
+
+		case *ast.Image:
+			out.WriteString(fmt.Sprintf("=> %s %s\n", node.Destination, inlineText(node, source)))
+			return ast.WalkSkipChildren, nil
+		}
+
+		return ast.WalkContinue, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
+
+// inlineText concatenates the text content of n's inline descendants,
+// discarding markup such as emphasis or link syntax.
+func inlineText(n ast.Node, source []byte) string {
+	var sb strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch v := c.(type) {
+		case *ast.Text:
+			sb.Write(v.Segment.Value(source))
+			if v.SoftLineBreak() {
+				sb.WriteString(" ")
+			}
+		case *ast.AutoLink:
+			sb.Write(v.Label(source))
+		default:
+			sb.WriteString(inlineText(c, source))
+		}
+	}
+
+	return sb.String()
+}
+
+// collectLinks gathers every link and image among n's inline descendants,
+// since both are rendered as standalone Gemtext "=>" lines.
+func collectLinks(n ast.Node, source []byte) []gemtextLink {
+	var links []gemtextLink
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch v := c.(type) {
+		case *ast.Link:
+			links = append(links, gemtextLink{
+				url:   string(v.Destination),
+				label: inlineText(v, source),
+			})
+		case *ast.Image:
+			links = append(links, gemtextLink{
+				url:   string(v.Destination),
+				label: inlineText(v, source),
+			})
+		case *ast.AutoLink:
+			links = append(links, gemtextLink{
+				url:   string(v.URL(source)),
+				label: string(v.Label(source)),
+			})
+		}
+		links = append(links, collectLinks(c, source)...)
+	}
+
+	return links
+}
+
+// linesNode is satisfied by block nodes (via ast.BaseBlock) that expose
+// their raw source lines.
+type linesNode interface {
+	Lines() *text.Segments
+}
+
+// codeBlockSource returns the raw source lines of a code block node.
+func codeBlockSource(n linesNode, source []byte) []byte {
+	var sb strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		sb.Write(segment.Value(source))
+	}
+
+	return []byte(sb.String())
+}
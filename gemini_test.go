@@ -0,0 +1,17 @@
+package main
+
+import "testing"
+
+func TestRenderGemtextInlineImage(t *testing.T) {
+	source := "Some text with ![an image](https://example.com/img.png) inline.\n"
+
+	got, err := renderGemtext([]byte(source))
+	if err != nil {
+		t.Fatalf("renderGemtext() error = %v", err)
+	}
+
+	want := "Some text with an image inline.\n=> https://example.com/img.png an image\n"
+	if got != want {
+		t.Errorf("renderGemtext() = %q, want %q", got, want)
+	}
+}
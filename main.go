@@ -2,9 +2,12 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
@@ -18,13 +21,24 @@ import (
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
+	"golang.org/x/sync/errgroup"
 )
 
 // Page represents a single page with its title, HTML content, and modification date.
 type Page struct {
 	Title            string
+	DisplayTitle     string
 	Content          string
 	ModificationDate time.Time
+	Draft            bool
+	Tags             []string
+	Categories       []string
+	Slug             string
+	Aliases          []string
+	Params           map[string]interface{}
+	Section          string
+	SourcePath       string
+	Markdown         []byte
 }
 
 // Footer represents the footer section in the template.
@@ -39,14 +53,28 @@ type Navbar struct {
 
 // Settings represents the configuration settings.
 type Settings struct {
-	GithubUsername         string `json:"githubUsername"`
-	WebsiteName            string `json:"websiteName"`
-	TemplateDir            string `json:"templateDir"`
-	MarkdownDir            string `json:"markdownDir"`
-	OutputDir              string `json:"outputDir"`
-	WebsiteURL             string `json:"websiteURL"`
-	WebsiteDescription     string `json:"websiteDescription"`
-	TimestampsFromFilename bool   `json:"timestampsFromFilename"`
+	GithubUsername         string   `json:"githubUsername"`
+	WebsiteName            string   `json:"websiteName"`
+	TemplateDir            string   `json:"templateDir"`
+	MarkdownDir            string   `json:"markdownDir"`
+	OutputDir              string   `json:"outputDir"`
+	WebsiteURL             string   `json:"websiteURL"`
+	WebsiteDescription     string   `json:"websiteDescription"`
+	TimestampsFromFilename bool     `json:"timestampsFromFilename"`
+	BuildDrafts            bool     `json:"buildDrafts"`
+	Taxonomies             []string `json:"taxonomies"`
+	Concurrency            int      `json:"concurrency"`
+	OutputFormats          []string `json:"outputFormats"`
+	ThemeDir               string   `json:"themeDir"`
+}
+
+// resolveWorkers returns configured if it is positive, or runtime.NumCPU()
+// otherwise.
+func resolveWorkers(configured int) int {
+	if configured > 0 {
+		return configured
+	}
+	return runtime.NumCPU()
 }
 
 // RSSItem represents an individual item in the RSS feed.
@@ -77,71 +105,171 @@ func checkDirectories(settings Settings) {
 	}
 }
 
+// markdownFile pairs a discovered markdown file's path with its os.FileInfo.
+type markdownFile struct {
+	path string
+	info os.FileInfo
+}
+
 // generatePages traverses the specified directory, reads markdown files,
-// converts them to HTML, and generates Page objects for each file.
-func generatePages(dirPath string, timestampsFromFilename bool) ([]Page, error) {
-	var pages []Page
+// converts them to HTML, and generates Page objects for each file. Front
+// matter in each file (if present) overrides the filename-derived title and
+// date, draft pages are skipped unless buildDrafts is set, and any "{{<
+// name >}}" shortcode invocations are expanded against templates before the
+// markdown is rendered. Markdown parsing and rendering for each file happens
+// in parallel across workers goroutines (runtime.NumCPU() if workers <= 0).
+func generatePages(dirPath string, timestampsFromFilename bool, buildDrafts bool, workers int, templates *template.Template) ([]Page, error) {
+	var files []markdownFile
 
 	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if !info.IsDir() && filepath.Ext(path) == ".md" && filepath.Base(path) != "README.md" {
-			filename := strings.TrimSuffix(info.Name(), ".md")
-			parts := strings.Split(filename, "-")
-
-			if len(parts) >= 3 && len(parts[0]) == 4 && len(parts[1]) == 2 && len(parts[2]) == 2 {
-				year, _ := strconv.Atoi(parts[0])
-				month, _ := strconv.Atoi(parts[1])
-				day, _ := strconv.Atoi(parts[2])
-				var title string
-				if len(parts) > 3 {
-					title = strings.Join(parts[3:], "-")
-				}
+		if info.IsDir() || filepath.Ext(path) != ".md" || filepath.Base(path) == "README.md" {
+			return nil
+		}
 
-				timestamp := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
-				content, err := os.ReadFile(path)
-				if err != nil {
-					return err
-				}
+		files = append(files, markdownFile{path: path, info: info})
 
-				htmlContent := renderMarkdown(content)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-				var page Page
-				if title != "" {
-					page.Title = fmt.Sprintf("%s-%s.html", timestamp.Format("2006-01-02"), title)
-				} else {
-					page.Title = fmt.Sprintf("%s.html", timestamp.Format("2006-01-02"))
-					log.Warnf("Markdown file '%s' is missing a title. Using date as the title.", filepath.Base(path))
-				}
-				page.ModificationDate = timestamp
-				page.Content = htmlContent
+	// Each worker writes to its own index, so the shared slice needs no
+	// mutex: indices never overlap between goroutines.
+	results := make([]*Page, len(files))
+	work := make(chan int)
 
-				pages = append(pages, page)
-			} else {
-				content, err := os.ReadFile(path)
+	g, ctx := errgroup.WithContext(context.Background())
+	for w := 0; w < resolveWorkers(workers); w++ {
+		g.Go(func() error {
+			for i := range work {
+				page, err := generatePage(files[i].path, files[i].info, dirPath, buildDrafts, templates)
 				if err != nil {
 					return err
 				}
+				results[i] = page
+			}
+			return nil
+		})
+	}
 
-				htmlContent := renderMarkdown(content)
+	g.Go(func() error {
+		defer close(work)
+		for i := range files {
+			select {
+			case work <- i:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
 
-				var page Page
-				page.Title = fmt.Sprintf("%s.html", filename)
-				page.ModificationDate = info.ModTime()
-				page.Content = htmlContent
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
 
-				log.Warnf("Markdown file '%s' does not follow the correct naming format (yyyy-mm-dd-title.md). Using filename as title.", filepath.Base(path))
+	pages := make([]Page, 0, len(results))
+	for _, page := range results {
+		if page != nil {
+			pages = append(pages, *page)
+		}
+	}
 
-				pages = append(pages, page)
-			}
+	return pages, nil
+}
+
+// generatePage reads a single markdown file and converts it into a Page,
+// applying its front matter (if present) and expanding any shortcode
+// invocations against templates before rendering. It returns a nil Page
+// (with a nil error) if the page is a draft and buildDrafts is false.
+func generatePage(path string, info os.FileInfo, dirPath string, buildDrafts bool, templates *template.Template) (*Page, error) {
+	filename := strings.TrimSuffix(info.Name(), ".md")
+	parts := strings.Split(filename, "-")
+
+	rawContent, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, delim, body := splitFrontMatter(rawContent)
+	var fm FrontMatter
+	if delim != "" {
+		fm, err = parseFrontMatter(raw, delim)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse front matter in '%s': %w", path, err)
 		}
+	}
 
-		return nil
-	})
+	if fm.Draft && !buildDrafts {
+		log.Infof("Skipping draft page '%s'", filepath.Base(path))
+		return nil, nil
+	}
+
+	var shortcodeOutput map[string]string
+	if templates != nil {
+		body, shortcodeOutput = expandShortcodes(templates, body)
+	}
+	htmlContent := renderMarkdown(body)
+	if len(shortcodeOutput) > 0 {
+		htmlContent = injectShortcodeOutput(htmlContent, shortcodeOutput)
+	}
+
+	var page Page
+	page.SourcePath = path
+	page.Markdown = body
+	page.Section = topLevelSection(dirPath, path)
+	page.Draft = fm.Draft
+	page.DisplayTitle = fm.Title
+	page.Tags = fm.Tags
+	page.Categories = fm.Categories
+	page.Slug = fm.Slug
+	page.Aliases = fm.Aliases
+	page.Params = fm.Params
+	page.Content = htmlContent
+
+	if len(parts) >= 3 && len(parts[0]) == 4 && len(parts[1]) == 2 && len(parts[2]) == 2 {
+		year, _ := strconv.Atoi(parts[0])
+		month, _ := strconv.Atoi(parts[1])
+		day, _ := strconv.Atoi(parts[2])
+		var title string
+		if len(parts) > 3 {
+			title = strings.Join(parts[3:], "-")
+		}
+
+		timestamp := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+
+		if title != "" {
+			page.Title = fmt.Sprintf("%s-%s.html", timestamp.Format("2006-01-02"), title)
+		} else {
+			page.Title = fmt.Sprintf("%s.html", timestamp.Format("2006-01-02"))
+			log.Warnf("Markdown file '%s' is missing a title. Using date as the title.", filepath.Base(path))
+		}
+		page.ModificationDate = timestamp
+	} else {
+		page.Title = fmt.Sprintf("%s.html", filename)
+		page.ModificationDate = info.ModTime()
 
-	return pages, err
+		log.Warnf("Markdown file '%s' does not follow the correct naming format (yyyy-mm-dd-title.md). Using filename as title.", filepath.Base(path))
+	}
+
+	if fm.Date != "" {
+		if parsed, err := parseFrontMatterDate(fm.Date); err == nil {
+			page.ModificationDate = parsed
+		} else {
+			log.Warnf("Markdown file '%s' has an unparsable 'date' front matter field: %v", filepath.Base(path), err)
+		}
+	}
+
+	if fm.Slug != "" {
+		page.Title = fmt.Sprintf("%s.html", fm.Slug)
+	}
+
+	return &page, nil
 }
 
 // renderMarkdown converts the given Markdown content to HTML using goldmark.
@@ -160,13 +288,43 @@ func renderMarkdown(content []byte) string {
 	return buf.String()
 }
 
-// generateButtons generates the HTML buttons for each page (excluding index.html) in descending order of modification date.
-func generateButtons(pages []Page) string {
-	// Sort the pages by modification date in descending order
+// displayTitle returns a page's front matter "title" if it set one,
+// otherwise strips its date-prefixed filename (e.g. "2024-01-02-first-post"
+// or "01-02-first-post") down to its human-readable title, falling back to
+// the page's modification date if nothing remains.
+func displayTitle(page Page) string {
+	if page.DisplayTitle != "" {
+		return page.DisplayTitle
+	}
+
+	title := strings.TrimSuffix(page.Title, ".html")
+
+	parts := strings.SplitN(title, "-", 4)
+	if len(parts) >= 3 && len(parts[0]) == 4 && len(parts[1]) == 2 && len(parts[2]) == 2 {
+		title = strings.Join(parts[3:], "-")
+	} else if len(parts) >= 2 && len(parts[0]) == 2 && len(parts[1]) == 2 {
+		title = strings.Join(parts[2:], "-")
+	}
+
+	if title == "" {
+		title = page.ModificationDate.Format("2006-01-02")
+	}
+
+	return title
+}
+
+// sortPagesByRecency sorts pages by modification date, newest first. It is
+// called once per build/rebuild (see Site.Build and Site.Rebuild) so every
+// consumer -- the index buttons, the RSS/Atom/JSON feeds, and the sitemap --
+// sees pages in the same order without each re-sorting the same slice.
+func sortPagesByRecency(pages []Page) {
 	sort.Slice(pages, func(i, j int) bool {
 		return pages[i].ModificationDate.After(pages[j].ModificationDate)
 	})
+}
 
+// generateButtons generates the HTML buttons for each page (excluding index.html) in descending order of modification date.
+func generateButtons(pages []Page) string {
 	var buttons strings.Builder
 	for _, page := range pages {
 		if page.Title != "index" {
@@ -269,33 +427,11 @@ func copyStaticFiles(outputDir string, templateDir string) error {
 
 // generateRSS generates the RSS feed based on the provided pages.
 func generateRSS(pages []Page, settings Settings) error {
-	// Sort the pages by modification date in descending order
-	sort.Slice(pages, func(i, j int) bool {
-		return pages[i].ModificationDate.After(pages[j].ModificationDate)
-	})
-
 	var rssItems []RSSItem
 	for _, page := range pages {
-		// Extract the title from the filename without the extension
-		titleParts := strings.SplitN(page.Title, ".", 2)
-		itemTitle := titleParts[0]
-
-		// Check if the itemTitle follows the yyyy-mm-dd-title format
-		parts := strings.SplitN(itemTitle, "-", 4)
-		if len(parts) >= 3 && len(parts[0]) == 4 && len(parts[1]) == 2 && len(parts[2]) == 2 {
-			itemTitle = strings.Join(parts[3:], "-")
-		} else if len(parts) >= 2 && len(parts[0]) == 2 && len(parts[1]) == 2 {
-			itemTitle = strings.Join(parts[2:], "-")
-		}
-
-		// If itemTitle is empty, use the date as the title
-		if itemTitle == "" {
-			itemTitle = page.ModificationDate.Format("2006-01-02")
-		}
-
 		item := RSSItem{
-			Title:       itemTitle,
-			Link:        itemTitle + ".html",
+			Title:       displayTitle(page),
+			Link:        pageURL(settings, page),
 			Description: page.Content,
 			PubDate:     page.ModificationDate.Format(time.RFC1123Z),
 		}
@@ -370,6 +506,9 @@ func configureViper() {
 	viper.BindEnv("websiteURL", "DOCR_WEBSITE_URL")
 	viper.BindEnv("websiteDescription", "DOCR_WEBSITE_DESCRIPTION")
 	viper.BindEnv("timestampsFromFilename", "DOCR_TIMESTAMPS_FROM_FILENAME")
+	viper.BindEnv("buildDrafts", "DOCR_BUILD_DRAFTS")
+	viper.BindEnv("concurrency", "DOCR_CONCURRENCY")
+	viper.BindEnv("themeDir", "DOCR_THEME_DIR")
 
 	if err := viper.ReadInConfig(); err != nil {
 		log.Warnf("Failed to read configuration file: %v", err)
@@ -379,143 +518,48 @@ func configureViper() {
 }
 
 func main() {
-	initLogger()
-	configureViper()
-
-	// Directory containing the markdown files
-	dirPath := viper.GetString("markdownDir")
-
-	// Output directory for generated HTML pages
-	outputDir := viper.GetString("outputDir")
-
-	// Template directory
-	templateDir := viper.GetString("templateDir")
-
-	// Load templates
-	templates := template.Must(template.ParseGlob(filepath.Join(templateDir, "*.html")))
-
-	// Generate pages
-	pages, err := generatePages(dirPath, viper.GetBool("timestampsFromFilename"))
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Create output directory if it doesn't exist
-	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
-		os.Mkdir(outputDir, os.ModePerm)
+	args := os.Args[1:]
+	isServe := len(args) > 0 && args[0] == "serve"
+
+	var buildDraftsFlag bool
+	var addrFlag string
+
+	// flag.Parse stops at the first positional argument, so a single global
+	// FlagSet would silently ignore "docr serve --addr=X" (the order users
+	// naturally type). Give "serve" its own FlagSet instead, parsed from the
+	// arguments after it.
+	if isServe {
+		fs := flag.NewFlagSet("serve", flag.ExitOnError)
+		fs.StringVar(&addrFlag, "addr", "127.0.0.1:1919", "address to serve on")
+		fs.BoolVar(&buildDraftsFlag, "build-drafts", false, "include draft pages in the build")
+		fs.Parse(args[1:])
+	} else {
+		fs := flag.NewFlagSet("docr", flag.ExitOnError)
+		fs.BoolVar(&buildDraftsFlag, "build-drafts", false, "include draft pages in the build")
+		fs.Parse(args)
 	}
 
-	// Copy static files to output directory
-	err = copyStaticFiles(outputDir, templateDir)
-	if err != nil {
-		log.Fatal(err)
-	}
+	initLogger()
+	configureViper()
 
-	// Load settings from Viper
 	var settings Settings
-	err = viper.Unmarshal(&settings)
-	if err != nil {
+	if err := viper.Unmarshal(&settings); err != nil {
 		log.Fatal(err)
 	}
+	settings.BuildDrafts = settings.BuildDrafts || buildDraftsFlag
 
-	// Check if the directories in settings exist
 	checkDirectories(settings)
 
-	// Generate individual pages
-	for _, page := range pages {
-		// Extract the title from the page's title and remove .html extension
-		pageTitle := strings.TrimSuffix(page.Title, ".html")
-
-		// Check if the pageTitle follows the yyyy-mm-dd-title.md format
-		parts := strings.SplitN(pageTitle, "-", 4)
-		if len(parts) >= 3 && len(parts[0]) == 4 && len(parts[1]) == 2 && len(parts[2]) == 2 {
-			pageTitle = strings.Join(parts[3:], "-")
-		} else if len(parts) >= 2 && len(parts[0]) == 2 && len(parts[1]) == 2 {
-			pageTitle = strings.Join(parts[2:], "-")
-		}
-
-		// If pageTitle is empty, use the date as the title
-		if pageTitle == "" {
-			pageTitle = page.ModificationDate.Format("2006-01-02")
-		}
+	site := NewSite(settings, settings.BuildDrafts)
 
-		// Create the output file
-		pageFile, err := os.Create(filepath.Join(outputDir, page.Title)) // Remove ".html" from here
-		if err != nil {
+	if isServe {
+		if err := serve(site, addrFlag); err != nil {
 			log.Fatal(err)
 		}
-		defer pageFile.Close()
-
-		// Combine the templates to generate the final HTML content for the page
-		data := struct {
-			Title            string
-			Content          string
-			GithubUsername   string
-			WebsiteName      string
-			Navbar           Navbar
-			Footer           Footer
-			ModificationDate string
-		}{
-			Title:            pageTitle, // Use extracted title without .html
-			Content:          page.Content,
-			GithubUsername:   settings.GithubUsername,
-			WebsiteName:      settings.WebsiteName,
-			Navbar:           Navbar{Pages: pages},
-			Footer:           Footer{Year: "2023"}, // Update with the appropriate year
-			ModificationDate: page.ModificationDate.Format(time.RFC1123),
-		}
-
-		err = templates.ExecuteTemplate(pageFile, "page.html", data)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		log.Printf("Generated page: %s\n", page.Title) // Remove ".html" from here
-	}
-
-	// Read the README.md file
-	readmeContent, err := os.ReadFile(filepath.Join(dirPath, "README.md"))
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Convert README.md content to HTML
-	readmeHTML := renderMarkdown(readmeContent)
-
-	// Combine the templates to generate the final HTML content for the index page
-	indexData := struct {
-		WebsiteName                     string
-		GithubUsername                  string
-		ReadmeContent                   string
-		Buttons                         string
-		Navbar                          Navbar
-		Footer                          Footer
-		PrettyFeedProcessingInstruction string
-	}{
-		WebsiteName:                     settings.WebsiteName,
-		GithubUsername:                  settings.GithubUsername,
-		ReadmeContent:                   readmeHTML,
-		Buttons:                         generateButtons(pages),
-		Navbar:                          Navbar{Pages: pages},
-		Footer:                          Footer{Year: "2023"}, // Update with the appropriate year
-		PrettyFeedProcessingInstruction: generatePrettyFeedProcessingInstruction(filepath.Join(settings.TemplateDir, "pretty-feed-v3.xsl")),
+		return
 	}
 
-	// Create the index.html file
-	indexFile, err := os.Create(filepath.Join(outputDir, "index.html"))
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer indexFile.Close()
-
-	err = templates.ExecuteTemplate(indexFile, "index.html", indexData)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Generate RSS feed
-	err = generateRSS(pages, settings)
-	if err != nil {
+	if err := site.Build(); err != nil {
 		log.Fatal(err)
 	}
 
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDisplayTitle(t *testing.T) {
+	modDate := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		page Page
+		want string
+	}{
+		{
+			name: "front matter title wins",
+			page: Page{Title: "2024-01-02-first-post.html", DisplayTitle: "Hello World"},
+			want: "Hello World",
+		},
+		{
+			name: "falls back to yyyy-mm-dd filename",
+			page: Page{Title: "2024-01-02-first-post.html"},
+			want: "first-post",
+		},
+		{
+			name: "falls back to mm-dd filename",
+			page: Page{Title: "01-02-first-post.html"},
+			want: "first-post",
+		},
+		{
+			name: "falls back to modification date when nothing remains",
+			page: Page{Title: "2024-01-02.html", ModificationDate: modDate},
+			want: "2024-01-02",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := displayTitle(tt.page); got != tt.want {
+				t.Errorf("displayTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
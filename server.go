@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// rebuildDebounce is how long to wait after the last filesystem event before
+// triggering a rebuild, so that a burst of writes (e.g. an editor's
+// save-then-touch) only triggers one rebuild.
+const rebuildDebounce = 200 * time.Millisecond
+
+// liveReloadScript is injected into every served HTML page. It opens an
+// EventSource to the /docr-livereload endpoint and reloads the page whenever
+// the server announces a rebuild.
+const liveReloadScript = `<script>new EventSource("/docr-livereload").onmessage = function () { location.reload(); };</script>`
+
+// serve builds site once, then serves OutputDir over HTTP at addr while
+// watching MarkdownDir and TemplateDir for changes, rebuilding and pushing a
+// live-reload signal to connected browsers whenever something changes.
+func serve(site *Site, addr string) error {
+	if err := site.Build(); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	watchedDirs := []string{site.Settings.MarkdownDir, site.Settings.TemplateDir}
+	if site.Settings.ThemeDir != "" {
+		watchedDirs = append(watchedDirs, site.Settings.ThemeDir)
+	}
+	for _, dir := range watchedDirs {
+		if err := watchRecursive(watcher, dir); err != nil {
+			return err
+		}
+	}
+
+	reloader := newReloadBroker()
+	go watchAndRebuild(site, watcher, reloader)
+
+	mux := http.NewServeMux()
+	mux.Handle("/docr-livereload", reloader)
+	mux.Handle("/", injectLiveReload(http.FileServer(http.Dir(site.Settings.OutputDir))))
+
+	log.Infof("Serving %s on http://%s", site.Settings.OutputDir, addr)
+
+	return http.ListenAndServe(addr, mux)
+}
+
+// watchRecursive adds dir and all of its subdirectories to watcher, since
+// fsnotify does not watch directories recursively on its own.
+func watchRecursive(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+// watchAndRebuild drains watcher's events, debounces them, and triggers an
+// incremental Site.Rebuild for the changed paths, notifying reloader on
+// success. pending and the debounce timer are only ever touched from this
+// goroutine, and Rebuild is only ever called from here, so two rebuilds can
+// never run concurrently or race over pending.
+func watchAndRebuild(site *Site, watcher *fsnotify.Watcher, reloader *reloadBroker) {
+	var pending []string
+
+	timer := time.NewTimer(rebuildDebounce)
+	if !timer.Stop() {
+		<-timer.C
+	}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+
+			pending = append(pending, event.Name)
+			timer.Reset(rebuildDebounce)
+
+		case <-timer.C:
+			changed := pending
+			pending = nil
+
+			if err := site.Rebuild(changed); err != nil {
+				log.Errorf("Rebuild failed: %v", err)
+				continue
+			}
+			log.Info("Rebuilt site, notifying browsers")
+			reloader.broadcast()
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Errorf("Watcher error: %v", err)
+		}
+	}
+}
+
+// reloadBroker is an http.Handler serving an EventSource stream that emits
+// one "reload" message per call to broadcast.
+type reloadBroker struct {
+	mu          sync.Mutex
+	subscribers []chan struct{}
+}
+
+func newReloadBroker() *reloadBroker {
+	return &reloadBroker{}
+}
+
+func (b *reloadBroker) broadcast() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, ch := range b.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (b *reloadBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := make(chan struct{}, 1)
+	b.mu.Lock()
+	b.subscribers = append(b.subscribers, ch)
+	b.mu.Unlock()
+
+	for {
+		select {
+		case <-ch:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// bufferedResponseWriter captures a handler's response so it can be
+// inspected and modified before being written to the real ResponseWriter.
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	if w.header == nil {
+		w.header = make(http.Header)
+	}
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// injectLiveReload wraps next, appending liveReloadScript just before
+// </body> in any response whose Content-Type is text/html.
+func injectLiveReload(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := &bufferedResponseWriter{header: make(http.Header)}
+		next.ServeHTTP(buf, r)
+
+		body := buf.body.Bytes()
+		if strings.Contains(buf.header.Get("Content-Type"), "text/html") {
+			body = bytes.Replace(body, []byte("</body>"), []byte(liveReloadScript+"</body>"), 1)
+		}
+
+		for key, values := range buf.header {
+			for _, value := range values {
+				w.Header().Add(key, value)
+			}
+		}
+		// The inner handler's Content-Length (if any) describes the
+		// pre-injection body and no longer matches once the script is
+		// spliced in; drop it so net/http recomputes it from body.
+		w.Header().Del("Content-Length")
+
+		if buf.status == 0 {
+			buf.status = http.StatusOK
+		}
+		w.WriteHeader(buf.status)
+		w.Write(body)
+	})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// shortcodeTag matches a Hugo-style shortcode invocation in markdown source,
+// e.g. `{{< youtube id="dQw4w9WgXcQ" >}}` or `{{< note This is a tip >}}`.
+var shortcodeTag = regexp.MustCompile(`\{\{<\s*(\w+)([^>]*)>\}\}`)
+
+// ShortcodeData is the template data passed to a shortcode template.
+type ShortcodeData struct {
+	// Args holds any bare (non "key=value") tokens, in order.
+	Args []string
+	// Params holds any "key=value" tokens, keyed by name.
+	Params map[string]string
+}
+
+// expandShortcodes renders every "{{< name ... >}}" invocation in body
+// against the "shortcodes/name.html" template -- resolved through the same
+// templateDir -> themeDir -> built-in layering every other layout uses,
+// since shortcode templates are parsed by the same parseTemplateFS walk as
+// partials -- and replaces the invocation with a placeholder token. It
+// returns the rewritten body alongside a placeholder -> rendered HTML map,
+// to be spliced into the final HTML by injectShortcodeOutput after markdown
+// rendering: goldmark's default (safe) renderer drops literal raw HTML
+// found in markdown source, so the shortcode's HTML can't be spliced in
+// before parsing. An invocation naming an unknown shortcode, or one whose
+// template fails to execute, is left untouched (and logged) so a typo
+// doesn't fail the whole build.
+func expandShortcodes(templates *template.Template, body []byte) ([]byte, map[string]string) {
+	output := make(map[string]string)
+	next := 0
+
+	expanded := shortcodeTag.ReplaceAllFunc(body, func(match []byte) []byte {
+		groups := shortcodeTag.FindSubmatch(match)
+		name := string(groups[1])
+
+		layout := "shortcodes/" + name + ".html"
+		if templates.Lookup(layout) == nil {
+			log.Warnf("Unknown shortcode '%s', leaving invocation untouched", name)
+			return match
+		}
+
+		var rendered bytes.Buffer
+		if err := templates.ExecuteTemplate(&rendered, layout, parseShortcodeArgs(string(groups[2]))); err != nil {
+			log.Warnf("Failed to render shortcode '%s': %v", name, err)
+			return match
+		}
+
+		// The "-end" suffix keeps one placeholder from being a prefix of
+		// another (e.g. "-1" of "-10") when they are replaced.
+		placeholder := fmt.Sprintf("docr-shortcode-placeholder-%d-end", next)
+		next++
+		output[placeholder] = rendered.String()
+
+		return []byte(placeholder)
+	})
+
+	return expanded, output
+}
+
+// injectShortcodeOutput replaces each placeholder produced by
+// expandShortcodes with its rendered HTML, once the markdown containing it
+// has already been converted to HTML.
+func injectShortcodeOutput(html string, output map[string]string) string {
+	for placeholder, rendered := range output {
+		html = strings.ReplaceAll(html, placeholder, rendered)
+	}
+
+	return html
+}
+
+// parseShortcodeArgs splits a shortcode's argument string into bare Args and
+// "key=value" Params, honoring double-quoted values that may contain spaces.
+func parseShortcodeArgs(raw string) ShortcodeData {
+	data := ShortcodeData{Params: make(map[string]string)}
+
+	for _, token := range splitShortcodeTokens(raw) {
+		if key, value, ok := strings.Cut(token, "="); ok {
+			data.Params[key] = strings.Trim(value, `"`)
+			continue
+		}
+		data.Args = append(data.Args, token)
+	}
+
+	return data
+}
+
+// splitShortcodeTokens splits a shortcode's argument string on whitespace,
+// keeping double-quoted substrings (which may themselves contain spaces)
+// intact as a single token.
+func splitShortcodeTokens(raw string) []string {
+	var tokens []string
+	var current strings.Builder
+	inQuotes := false
+
+	for _, r := range raw {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuotes:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+
+	return tokens
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"text/template"
+)
+
+func TestExpandShortcodes(t *testing.T) {
+	templates := template.Must(template.New("shortcodes/note.html").
+		Parse(`<blockquote class="note">{{range .Args}}{{.}} {{end}}</blockquote>`))
+	template.Must(templates.New("shortcodes/youtube.html").
+		Parse(`<iframe src="https://youtube.com/embed/{{.Params.id}}"></iframe>`))
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "positional args",
+			body: `Before. {{< note This is a tip >}} After.`,
+			want: `Before. <blockquote class="note">This is a tip </blockquote> After.`,
+		},
+		{
+			name: "key=value params",
+			body: `{{< youtube id="dQw4w9WgXcQ" >}}`,
+			want: `<iframe src="https://youtube.com/embed/dQw4w9WgXcQ"></iframe>`,
+		},
+		{
+			name: "unknown shortcode left untouched",
+			body: `{{< nonexistent foo >}}`,
+			want: `{{< nonexistent foo >}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expanded, output := expandShortcodes(templates, []byte(tt.body))
+			got := injectShortcodeOutput(string(expanded), output)
+			if got != tt.want {
+				t.Errorf("expandShortcodes()+injectShortcodeOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseShortcodeArgs(t *testing.T) {
+	data := parseShortcodeArgs(` id="dQw4w9WgXcQ" autoplay=true loop `)
+
+	if data.Params["id"] != "dQw4w9WgXcQ" {
+		t.Errorf("Params[id] = %q, want %q", data.Params["id"], "dQw4w9WgXcQ")
+	}
+	if data.Params["autoplay"] != "true" {
+		t.Errorf("Params[autoplay] = %q, want %q", data.Params["autoplay"], "true")
+	}
+	if len(data.Args) != 1 || data.Args[0] != "loop" {
+		t.Errorf("Args = %v, want [loop]", data.Args)
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Site holds everything needed to build a docr site: its settings, the
+// parsed templates, and the generated pages. It is the reusable entry point
+// for both a one-shot build (Build) and the incremental rebuilds driven by
+// "docr serve" (Rebuild).
+type Site struct {
+	Settings    Settings
+	BuildDrafts bool
+
+	Templates *template.Template
+	Pages     []Page
+}
+
+// NewSite creates a Site ready to Build from the given settings.
+func NewSite(settings Settings, buildDrafts bool) *Site {
+	return &Site{
+		Settings:    settings,
+		BuildDrafts: buildDrafts,
+	}
+}
+
+// Build performs a full site build: it (re)loads templates, walks
+// MarkdownDir to regenerate every page, and renders all output.
+func (s *Site) Build() error {
+	templates, err := loadTemplates(s.Settings.TemplateDir, s.Settings.ThemeDir)
+	if err != nil {
+		return err
+	}
+	s.Templates = templates
+
+	pages, err := generatePages(s.Settings.MarkdownDir, s.Settings.TimestampsFromFilename, s.BuildDrafts, s.Settings.Concurrency, s.Templates)
+	if err != nil {
+		return err
+	}
+	sortPagesByRecency(pages)
+	s.Pages = pages
+
+	return s.renderAll()
+}
+
+// Rebuild regenerates only the pages whose source file appears in changed,
+// merges them into the cached Pages slice, and re-renders all output. If any
+// changed path lives under TemplateDir, templates are reloaded and a full
+// Build is performed instead, since a template edit can affect every page.
+func (s *Site) Rebuild(changed []string) error {
+	for _, path := range changed {
+		if isWithinDir(s.Settings.TemplateDir, path) || (s.Settings.ThemeDir != "" && isWithinDir(s.Settings.ThemeDir, path)) {
+			log.Info("Template changed, performing a full rebuild")
+			return s.Build()
+		}
+	}
+
+	for _, path := range changed {
+		if filepath.Ext(path) != ".md" || !isWithinDir(s.Settings.MarkdownDir, path) {
+			continue
+		}
+
+		s.Pages = removePageBySource(s.Pages, path)
+
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		page, err := generatePage(path, info, s.Settings.MarkdownDir, s.BuildDrafts, s.Templates)
+		if err != nil {
+			return err
+		}
+		if page != nil {
+			s.Pages = append(s.Pages, *page)
+		}
+	}
+
+	sortPagesByRecency(s.Pages)
+
+	return s.renderAll()
+}
+
+// renderAll writes every output artifact (static files, section and
+// taxonomy list pages, individual pages, the index, and each format named
+// in Settings.OutputFormats, which is the RSS feed when unset) for the
+// Site's current Pages.
+func (s *Site) renderAll() error {
+	settings := s.Settings
+	outputDir := settings.OutputDir
+
+	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
+		if err := os.Mkdir(outputDir, os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	if err := copyStaticFiles(outputDir, settings.TemplateDir); err != nil {
+		return err
+	}
+
+	sections := groupBySection(s.Pages)
+	for section, sectionPages := range sections {
+		if err := renderSectionPage(s.Templates, outputDir, settings, section, sectionPages); err != nil {
+			return err
+		}
+	}
+
+	taxonomies := buildTaxonomies(s.Pages, settings.Taxonomies)
+	for taxonomyName, terms := range taxonomies {
+		for term, termPages := range terms {
+			if err := renderTaxonomyPage(s.Templates, outputDir, settings, taxonomyName, term, termPages); err != nil {
+				return err
+			}
+		}
+	}
+	if err := renderTermCloudPage(s.Templates, outputDir, settings, "tags", taxonomies["tags"]); err != nil {
+		return err
+	}
+
+	if err := s.renderPages(); err != nil {
+		return err
+	}
+
+	if err := s.renderIndex(); err != nil {
+		return err
+	}
+
+	return renderOutputFormats(s)
+}
+
+// renderPages writes every page's output file in parallel across
+// s.Settings.Concurrency workers (runtime.NumCPU() if unset).
+func (s *Site) renderPages() error {
+	work := make(chan Page)
+
+	g, ctx := errgroup.WithContext(context.Background())
+	for w := 0; w < resolveWorkers(s.Settings.Concurrency); w++ {
+		g.Go(func() error {
+			for page := range work {
+				if err := s.renderPage(page); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	g.Go(func() error {
+		defer close(work)
+		for _, page := range s.Pages {
+			select {
+			case work <- page:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+
+	return g.Wait()
+}
+
+// renderPage writes a single page's output file using the "page.html"
+// layout, or "_default/single.html" if no "page.html" template exists.
+func (s *Site) renderPage(page Page) error {
+	settings := s.Settings
+	pageTitle := displayTitle(page)
+
+	pageFile, err := os.Create(filepath.Join(settings.OutputDir, page.Title))
+	if err != nil {
+		return err
+	}
+	defer pageFile.Close()
+
+	data := struct {
+		Title            string
+		Content          string
+		GithubUsername   string
+		WebsiteName      string
+		Navbar           Navbar
+		Footer           Footer
+		ModificationDate string
+	}{
+		Title:            pageTitle,
+		Content:          page.Content,
+		GithubUsername:   settings.GithubUsername,
+		WebsiteName:      settings.WebsiteName,
+		Navbar:           Navbar{Pages: s.Pages},
+		Footer:           Footer{Year: "2023"},
+		ModificationDate: page.ModificationDate.Format(time.RFC1123),
+	}
+
+	layout := resolveLayout(s.Templates, "page.html", "_default/single.html")
+	if err := s.Templates.ExecuteTemplate(pageFile, layout, data); err != nil {
+		return err
+	}
+
+	log.Printf("Generated page: %s\n", page.Title)
+
+	return nil
+}
+
+// renderIndex reads README.md and writes the site's index.html.
+func (s *Site) renderIndex() error {
+	settings := s.Settings
+
+	readmeContent, err := os.ReadFile(filepath.Join(settings.MarkdownDir, "README.md"))
+	if err != nil {
+		return err
+	}
+	readmeHTML := renderMarkdown(readmeContent)
+
+	indexData := struct {
+		WebsiteName                     string
+		GithubUsername                  string
+		ReadmeContent                   string
+		Buttons                         string
+		Navbar                          Navbar
+		Footer                          Footer
+		PrettyFeedProcessingInstruction string
+	}{
+		WebsiteName:                     settings.WebsiteName,
+		GithubUsername:                  settings.GithubUsername,
+		ReadmeContent:                   readmeHTML,
+		Buttons:                         generateButtons(s.Pages),
+		Navbar:                          Navbar{Pages: s.Pages},
+		Footer:                          Footer{Year: "2023"},
+		PrettyFeedProcessingInstruction: generatePrettyFeedProcessingInstruction(filepath.Join(settings.TemplateDir, "pretty-feed-v3.xsl")),
+	}
+
+	indexFile, err := os.Create(filepath.Join(settings.OutputDir, "index.html"))
+	if err != nil {
+		return err
+	}
+	defer indexFile.Close()
+
+	layout := resolveLayout(s.Templates, "index.html", "_default/index.html")
+	return s.Templates.ExecuteTemplate(indexFile, layout, indexData)
+}
+
+// isWithinDir reports whether path lives inside dir.
+func isWithinDir(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// removePageBySource returns pages with any entry whose SourcePath matches
+// path removed.
+func removePageBySource(pages []Page, path string) []Page {
+	filtered := pages[:0]
+	for _, page := range pages {
+		if page.SourcePath != path {
+			filtered = append(filtered, page)
+		}
+	}
+
+	return filtered
+}
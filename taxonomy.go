@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// topLevelSection returns the top-level markdown subdirectory that path
+// lives in, relative to dirPath, or "" if path is directly inside dirPath.
+func topLevelSection(dirPath string, path string) string {
+	rel, err := filepath.Rel(dirPath, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return ""
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	return parts[0]
+}
+
+// ListData is the template data exposed to section and taxonomy list pages.
+type ListData struct {
+	WebsiteName    string
+	GithubUsername string
+	Pages          []Page
+	Section        string
+	Taxonomy       string
+	Term           string
+	Navbar         Navbar
+	Footer         Footer
+}
+
+// TermCloudData is the template data exposed to a taxonomy's top-level
+// term-cloud page (e.g. /tags/index.html).
+type TermCloudData struct {
+	WebsiteName    string
+	GithubUsername string
+	Taxonomy       string
+	Terms          []string
+	Navbar         Navbar
+	Footer         Footer
+}
+
+// groupBySection groups pages by their top-level markdown subdirectory.
+// Pages at the root of MarkdownDir (empty Section) are not grouped into a
+// section list page.
+func groupBySection(pages []Page) map[string][]Page {
+	sections := make(map[string][]Page)
+	for _, page := range pages {
+		if page.Section == "" {
+			continue
+		}
+		sections[page.Section] = append(sections[page.Section], page)
+	}
+
+	return sections
+}
+
+// buildTaxonomies groups pages by taxonomy term for the built-in "tags" and
+// "categories" taxonomies plus any custom taxonomy names declared in
+// settings.json. Custom taxonomy terms are read from the page's front
+// matter Params map.
+func buildTaxonomies(pages []Page, customTaxonomies []string) map[string]map[string][]Page {
+	taxonomies := map[string]map[string][]Page{
+		"tags":       {},
+		"categories": {},
+	}
+	for _, name := range customTaxonomies {
+		if _, ok := taxonomies[name]; !ok {
+			taxonomies[name] = map[string][]Page{}
+		}
+	}
+
+	for _, page := range pages {
+		for _, term := range page.Tags {
+			taxonomies["tags"][term] = append(taxonomies["tags"][term], page)
+		}
+		for _, term := range page.Categories {
+			taxonomies["categories"][term] = append(taxonomies["categories"][term], page)
+		}
+
+		for _, name := range customTaxonomies {
+			terms := toStringSlice(page.Params[name])
+			for _, term := range terms {
+				taxonomies[name][term] = append(taxonomies[name][term], page)
+			}
+		}
+	}
+
+	return taxonomies
+}
+
+// renderSectionPage writes the list page for a single section using the
+// section layout lookup chain.
+func renderSectionPage(templates *template.Template, outputDir string, settings Settings, section string, pages []Page) error {
+	layout := resolveLayout(templates, sectionLayoutCandidates(section)...)
+	if layout == "" {
+		log.Warnf("No layout found for section '%s', skipping", section)
+		return nil
+	}
+
+	data := ListData{
+		WebsiteName:    settings.WebsiteName,
+		GithubUsername: settings.GithubUsername,
+		Pages:          pages,
+		Section:        section,
+		Navbar:         Navbar{Pages: pages},
+		Footer:         Footer{Year: "2023"},
+	}
+
+	return renderListFile(templates, layout, filepath.Join(outputDir, section, "index.html"), data)
+}
+
+// renderTaxonomyPage writes the list page for a single taxonomy term using
+// the taxonomy layout lookup chain.
+func renderTaxonomyPage(templates *template.Template, outputDir string, settings Settings, taxonomy string, term string, pages []Page) error {
+	layout := resolveLayout(templates, taxonomyLayoutCandidates(taxonomy)...)
+	if layout == "" {
+		log.Warnf("No layout found for taxonomy '%s', skipping term '%s'", taxonomy, term)
+		return nil
+	}
+
+	data := ListData{
+		WebsiteName:    settings.WebsiteName,
+		GithubUsername: settings.GithubUsername,
+		Pages:          pages,
+		Taxonomy:       taxonomy,
+		Term:           term,
+		Navbar:         Navbar{Pages: pages},
+		Footer:         Footer{Year: "2023"},
+	}
+
+	outputPath := filepath.Join(outputDir, taxonomy, sanitizeTerm(term), "index.html")
+	return renderListFile(templates, layout, outputPath, data)
+}
+
+// renderTermCloudPage writes the top-level term-cloud page for a taxonomy
+// (e.g. /tags/index.html), listing every known term.
+func renderTermCloudPage(templates *template.Template, outputDir string, settings Settings, taxonomy string, terms map[string][]Page) error {
+	layout := resolveLayout(templates, filepath.ToSlash(filepath.Join("taxonomy", taxonomy+"-terms.html")), "_default/terms.html")
+	if layout == "" {
+		log.Warnf("No layout found for the '%s' term-cloud page, skipping", taxonomy)
+		return nil
+	}
+
+	termNames := make([]string, 0, len(terms))
+	for term := range terms {
+		termNames = append(termNames, term)
+	}
+	sort.Strings(termNames)
+
+	data := TermCloudData{
+		WebsiteName:    settings.WebsiteName,
+		GithubUsername: settings.GithubUsername,
+		Taxonomy:       taxonomy,
+		Terms:          termNames,
+		Navbar:         Navbar{},
+		Footer:         Footer{Year: "2023"},
+	}
+
+	return renderListFile(templates, layout, filepath.Join(outputDir, taxonomy, "index.html"), data)
+}
+
+// renderListFile executes the named layout with data and writes the result
+// to outputPath, creating any missing parent directories.
+func renderListFile(templates *template.Template, layout string, outputPath string, data interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := templates.ExecuteTemplate(file, layout, data); err != nil {
+		return fmt.Errorf("failed to render '%s' with layout '%s': %w", outputPath, layout, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestBuildTaxonomies(t *testing.T) {
+	pages := []Page{
+		{Title: "a.html", Tags: []string{"go", "cli"}, Categories: []string{"dev"}},
+		{Title: "b.html", Tags: []string{"go"}, Params: map[string]interface{}{"series": []interface{}{"backend"}}},
+	}
+
+	taxonomies := buildTaxonomies(pages, []string{"series"})
+
+	if got := len(taxonomies["tags"]["go"]); got != 2 {
+		t.Errorf("tags[go] = %d pages, want 2", got)
+	}
+	if got := len(taxonomies["tags"]["cli"]); got != 1 {
+		t.Errorf("tags[cli] = %d pages, want 1", got)
+	}
+	if got := len(taxonomies["categories"]["dev"]); got != 1 {
+		t.Errorf("categories[dev] = %d pages, want 1", got)
+	}
+	if got := len(taxonomies["series"]["backend"]); got != 1 {
+		t.Errorf("series[backend] = %d pages, want 1", got)
+	}
+}
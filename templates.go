@@ -0,0 +1,111 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// loadTemplates builds the final *template.Template for a site by layering,
+// in increasing priority, the built-in default theme, the optional
+// themeDir's layouts/ directory, and the user's templateDir -- mirroring
+// Hugo's appendThemeTemplates lookup order. Each layer's templates are
+// parsed under the same name (its path relative to that layer's root), so a
+// later layer overrides an earlier one: a site can override any built-in or
+// theme file just by placing a same-named file in its own templateDir, and
+// nested layouts such as "taxonomy/tags.html", "partials/head.html", or
+// "shortcodes/note.html" are addressable by name in every layer. Shortcode
+// templates are invoked from markdown content via expandShortcodes, not
+// referenced directly by a layout.
+func loadTemplates(templateDir string, themeDir string) (*template.Template, error) {
+	root := template.New("")
+
+	builtin, err := fs.Sub(builtinThemeFS, builtinThemeRoot)
+	if err != nil {
+		return nil, err
+	}
+	if err := parseTemplateFS(root, builtin); err != nil {
+		return nil, err
+	}
+
+	if themeDir != "" {
+		themeLayouts := filepath.Join(themeDir, "layouts")
+		if _, err := os.Stat(themeLayouts); err == nil {
+			if err := parseTemplateFS(root, os.DirFS(themeLayouts)); err != nil {
+				return nil, err
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	if err := parseTemplateFS(root, os.DirFS(templateDir)); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// parseTemplateFS parses every ".html" file in fsys into root, naming each
+// template by its path relative to fsys's root (using "/" as the
+// separator).
+func parseTemplateFS(root *template.Template, fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || filepath.Ext(path) != ".html" {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+
+		_, err = root.New(filepath.ToSlash(path)).Parse(string(content))
+		return err
+	})
+}
+
+// resolveLayout returns the first template name in candidates that has been
+// parsed into templates, or "" if none of them match.
+func resolveLayout(templates *template.Template, candidates ...string) string {
+	for _, name := range candidates {
+		if templates.Lookup(name) != nil {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// taxonomyLayoutCandidates returns the layout lookup chain for a taxonomy
+// term list page, modeled on Hugo's "taxonomy/{name}.html" ->
+// "_default/taxonomy.html" -> "_default/list.html" chain.
+func taxonomyLayoutCandidates(taxonomy string) []string {
+	return []string{
+		filepath.ToSlash(filepath.Join("taxonomy", taxonomy+".html")),
+		"_default/taxonomy.html",
+		"_default/list.html",
+	}
+}
+
+// sectionLayoutCandidates returns the layout lookup chain for a section list
+// page, modeled on Hugo's "section/{name}.html" -> "_default/section.html"
+// -> "_default/list.html" chain.
+func sectionLayoutCandidates(section string) []string {
+	return []string{
+		filepath.ToSlash(filepath.Join("section", section+".html")),
+		"_default/section.html",
+		"_default/list.html",
+	}
+}
+
+// sanitizeTerm converts a taxonomy term into a URL-safe path segment.
+func sanitizeTerm(term string) string {
+	return strings.ToLower(strings.ReplaceAll(term, " ", "-"))
+}
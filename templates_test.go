@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+// TestSectionLayoutFallsBackToDefaultList guards against the built-in theme
+// shipping no "_default/section.html" and the section lookup chain having no
+// further fallback, which left every section list page unrendered.
+func TestSectionLayoutFallsBackToDefaultList(t *testing.T) {
+	templates, err := loadTemplates(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("loadTemplates() error = %v", err)
+	}
+
+	got := resolveLayout(templates, sectionLayoutCandidates("posts")...)
+	want := "_default/list.html"
+	if got != want {
+		t.Errorf("resolveLayout(sectionLayoutCandidates(\"posts\")) = %q, want %q", got, want)
+	}
+}
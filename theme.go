@@ -0,0 +1,16 @@
+package main
+
+import "embed"
+
+// builtinThemeFS embeds docr's built-in default theme, used as the base
+// layer beneath a site's optional themeDir and its templateDir. The "all:"
+// prefix is required so that embed includes the theme's "_default"
+// directory, which it would otherwise treat as hidden.
+//
+//go:embed all:themes/default/layouts
+var builtinThemeFS embed.FS
+
+// builtinThemeRoot is builtinThemeFS's layouts root, stripped off so that
+// embedded template names line up with themeDir and templateDir names (e.g.
+// "_default/single.html" rather than "themes/default/layouts/_default/single.html").
+const builtinThemeRoot = "themes/default/layouts"